@@ -0,0 +1,40 @@
+package pkger
+
+import "compress/gzip"
+
+// BuildOptions controls how `pkger generate` should write the embedded
+// bundle into pkged.go, such as which compression level to target.
+//
+// Scope: this tree has no `pkger generate` codegen path to wire
+// BuildOptions into (there is no cmd/ package here at all). pkging/zipfs
+// accepts the same gzip levels through its own SetCompression, but nothing
+// here passes BuildOptions to it, selects zipfs over pkging/mem, or drives
+// it from a generate invocation.
+// That generator wiring is left for whoever adds `pkger generate` to this
+// tree; BuildOptions/WithCompression exist so that work has a settled
+// shape to target.
+type BuildOptions struct {
+	Compression int
+}
+
+// NewBuildOptions returns the default BuildOptions: gzip.NoCompression, so
+// existing projects keep generating the historical pkging/mem-backed,
+// uncompressed pkged.go until they opt in.
+func NewBuildOptions() BuildOptions {
+	return BuildOptions{
+		Compression: gzip.NoCompression,
+	}
+}
+
+// BuildOption configures a BuildOptions.
+type BuildOption func(*BuildOptions)
+
+// WithCompression sets BuildOptions.Compression to the given gzip level
+// (see compress/gzip). gzip.NoCompression is the default and keeps entries
+// uncompressed. See BuildOptions for the current scope of what consumes
+// this.
+func WithCompression(level int) BuildOption {
+	return func(o *BuildOptions) {
+		o.Compression = level
+	}
+}
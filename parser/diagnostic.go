@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/markbates/pkger/pkging"
+)
+
+// Severity classifies a Diagnostic emitted while walking a file.
+type Severity int
+
+const (
+	// SeverityWarning marks a call the visitor could only partially
+	// resolve (e.g. a non-constant operand in a concatenation): the
+	// longest constant prefix was still bundled, but the remainder is
+	// unknown until runtime.
+	SeverityWarning Severity = iota
+	// SeverityError marks a call the visitor couldn't resolve at all.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic reports a problem the visitor ran into while resolving a
+// pkger call, with enough source position information for an editor or CI
+// to point at the offending call.
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Message  string
+	CallExpr string
+}
+
+// String formats d in the gopls/go vet position style, with the severity
+// ahead of the message: "file:line:col: severity: message".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+}
+
+// Result is what Run produces: every bundle path the visitor discovered,
+// plus any diagnostics raised while discovering them.
+type Result struct {
+	Paths       []pkging.Path
+	Diagnostics []Diagnostic
+}
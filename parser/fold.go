@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	gopath "path"
+)
+
+// resolvePrefix resolves expr to a string, possibly only partially. It
+// returns the longest constant prefix it can determine and whether that
+// prefix is the *complete* value of expr. Callers that get back
+// complete == false should treat the prefix as a directory that needs to be
+// bundled wholesale, since the remainder can't be known until runtime.
+//
+// Beyond plain constants, this handles `+` concatenation trees (so
+// "/assets/" + name + ".tmpl" yields the prefix "/assets/") and
+// `path.Join`/`filepath.Join` calls whose leading arguments are
+// constant-foldable even when trailing ones are not.
+func (v *visitor) resolvePrefix(expr ast.Expr) (prefix string, complete bool, ok bool) {
+	if s, ok := v.constString(expr); ok {
+		return s, true, true
+	}
+
+	switch t := expr.(type) {
+	case *ast.ParenExpr:
+		return v.resolvePrefix(t.X)
+	case *ast.BinaryExpr:
+		if t.Op != token.ADD {
+			return "", false, false
+		}
+		lhs, lhsComplete, ok := v.resolvePrefix(t.X)
+		if !ok {
+			return "", false, false
+		}
+		if !lhsComplete {
+			return lhs, false, true
+		}
+		rhs, rhsComplete, ok := v.resolvePrefix(t.Y)
+		if !ok {
+			return lhs, false, true
+		}
+		return lhs + rhs, rhsComplete, true
+	case *ast.CallExpr:
+		return v.resolveJoin(t)
+	}
+
+	return "", false, false
+}
+
+// resolveJoin handles `path.Join`/`filepath.Join` calls, folding as many
+// leading arguments as are constant-foldable and stopping at the first one
+// that isn't.
+func (v *visitor) resolveJoin(expr *ast.CallExpr) (prefix string, complete bool, ok bool) {
+	sel, ok := expr.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false, false
+	}
+
+	obj, ok := v.types.Uses[sel.Sel]
+	if !ok {
+		return "", false, false
+	}
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Name() != "Join" {
+		return "", false, false
+	}
+	switch fn.Pkg().Path() {
+	case "path", "path/filepath":
+	default:
+		return "", false, false
+	}
+
+	var parts []string
+	for i, a := range expr.Args {
+		s, partComplete, ok := v.resolvePrefix(a)
+		if !ok {
+			break
+		}
+		parts = append(parts, s)
+		if !partComplete {
+			return gopath.Join(parts...), false, true
+		}
+		if i == len(expr.Args)-1 {
+			return gopath.Join(parts...), true, true
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", false, false
+	}
+
+	return gopath.Join(parts...), false, true
+}
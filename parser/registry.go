@@ -0,0 +1,33 @@
+package parser
+
+// argExtractor describes how to pull a bundled path argument out of a call
+// to a registered function. ArgIndex is the zero-based position of the
+// argument that carries the path; Arity, when non-zero, is the exact number
+// of arguments the call must have (used by `Walk`, which also takes a
+// callback).
+type argExtractor struct {
+	ArgIndex int
+	Arity    int
+}
+
+// pathFuncs maps "importPath.FuncName" to the extractor describing how to
+// pull the bundled path out of a call to that function. It is seeded with
+// the full pkger path-accepting API and can be extended at runtime via
+// RegisterPathFunc so third-party wrappers around pkger are recognized too.
+var pathFuncs = map[string]argExtractor{
+	pkgerImportPath + ".Open":     {ArgIndex: 0},
+	pkgerImportPath + ".Include":  {ArgIndex: 0},
+	pkgerImportPath + ".Stat":     {ArgIndex: 0},
+	pkgerImportPath + ".Dir":      {ArgIndex: 0},
+	pkgerImportPath + ".ReadFile": {ArgIndex: 0},
+	pkgerImportPath + ".Parse":    {ArgIndex: 0},
+	pkgerImportPath + ".Walk":     {ArgIndex: 0, Arity: 2},
+}
+
+// RegisterPathFunc teaches the parser that calls to funcName in pkgPath pin
+// a bundle path in their argIndex'th argument, so third-party wrappers
+// around pkger (e.g. `mylib.MustOpen`) are discovered the same way the
+// built-in pkger API is. It is safe to call from an init func.
+func RegisterPathFunc(pkgPath, funcName string, argIndex int) {
+	pathFuncs[pkgPath+"."+funcName] = argExtractor{ArgIndex: argIndex}
+}
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/markbates/pkger/here"
+	"github.com/stretchr/testify/require"
+)
+
+// testdataInfo is the here.Info for the fixtures under ./testdata, used to
+// exercise each entry in the built-in pathFuncs table.
+func testdataInfo() here.Info {
+	dir, _ := filepath.Abs("testdata")
+	return here.Info{
+		Dir:        dir,
+		ImportPath: "github.com/markbates/pkger/parser/testdata",
+	}
+}
+
+func Test_Visitor_RecognizesPathFuncs(t *testing.T) {
+	files := []string{
+		"open.go",
+		"include.go",
+		"stat.go",
+		"dir.go",
+		"readfile.go",
+		"walk.go",
+		"aliased.go",
+		"parse.go",
+	}
+
+	info := testdataInfo()
+
+	for _, file := range files {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			r := require.New(t)
+
+			p := filepath.Join(info.Dir, file)
+			v, err := newVisitor(p, info)
+			r.NoError(err)
+
+			result, err := v.Run()
+			r.NoError(err)
+			r.Len(result.Paths, 1)
+			r.Empty(result.Diagnostics)
+		})
+	}
+}
+
+func Test_Visitor_FoldsPartialPaths(t *testing.T) {
+	files := []string{
+		"concat.go",
+		"join.go",
+	}
+
+	info := testdataInfo()
+
+	for _, file := range files {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			r := require.New(t)
+
+			p := filepath.Join(info.Dir, file)
+			v, err := newVisitor(p, info)
+			r.NoError(err)
+
+			result, err := v.Run()
+			r.NoError(err)
+			r.Len(result.Paths, 1)
+			r.Len(result.Diagnostics, 1)
+			r.Equal(SeverityWarning, result.Diagnostics[0].Severity)
+		})
+	}
+}
+
+func Test_RegisterPathFunc(t *testing.T) {
+	r := require.New(t)
+
+	const key = "github.com/markbates/pkger/parser/testdata/wrapper.MustOpen"
+
+	RegisterPathFunc("github.com/markbates/pkger/parser/testdata/wrapper", "MustOpen", 0)
+	defer delete(pathFuncs, key)
+
+	_, ok := pathFuncs[key]
+	r.True(ok)
+
+	info := testdataInfo()
+	p := filepath.Join(info.Dir, "registered.go")
+	v, err := newVisitor(p, info)
+	r.NoError(err)
+
+	result, err := v.Run()
+	r.NoError(err)
+	r.Len(result.Paths, 1)
+	r.Empty(result.Diagnostics)
+}
+
+func Test_Visitor_RecognizesFileSystemOpen(t *testing.T) {
+	r := require.New(t)
+
+	info := testdataInfo()
+	p := filepath.Join(info.Dir, "filesystemopen.go")
+	v, err := newVisitor(p, info)
+	r.NoError(err)
+
+	result, err := v.Run()
+	r.NoError(err)
+	// one path from pkger.Dir(...) and one from the .Open(...) method call
+	// on the http.FileSystem it returns.
+	r.Len(result.Paths, 2)
+	r.Empty(result.Diagnostics)
+}
+
+func Test_Visitor_SurfacesPackageLoadErrors(t *testing.T) {
+	r := require.New(t)
+
+	dir, err := filepath.Abs("testdata/typeerror")
+	r.NoError(err)
+	info := here.Info{
+		Dir:        dir,
+		ImportPath: "github.com/markbates/pkger/parser/testdata/typeerror",
+	}
+
+	v, err := newVisitor(filepath.Join(dir, "typeerror.go"), info)
+	r.NoError(err)
+
+	result, err := v.Run()
+	r.NoError(err)
+	r.NotEmpty(result.Diagnostics)
+	r.Equal(SeverityError, result.Diagnostics[0].Severity)
+
+	// the type error doesn't stop the walk: the well-formed pkger.Open
+	// call earlier in the same file is still found.
+	r.Len(result.Paths, 1)
+}
+
+func Test_Diagnostic_String(t *testing.T) {
+	r := require.New(t)
+
+	d := Diagnostic{
+		Pos:      token.Position{Filename: "testdata/walk.go", Line: 7, Column: 2},
+		Severity: SeverityError,
+		Message:  "`Walk` requires two arguments",
+	}
+
+	r.Equal("testdata/walk.go:7:2: error: `Walk` requires two arguments", d.String())
+}
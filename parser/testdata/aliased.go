@@ -0,0 +1,7 @@
+package testdata
+
+import p "github.com/markbates/pkger"
+
+func useAliasedOpen() {
+	p.Open("/assets/aliased.txt")
+}
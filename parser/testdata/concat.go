@@ -0,0 +1,9 @@
+package testdata
+
+import "github.com/markbates/pkger"
+
+const assetsDir = "/assets/"
+
+func useConcat(name string) {
+	pkger.Open(assetsDir + name + ".tmpl")
+}
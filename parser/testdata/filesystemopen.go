@@ -0,0 +1,8 @@
+package testdata
+
+import "github.com/markbates/pkger"
+
+func useFileSystemOpen() {
+	fs := pkger.Dir("/assets/sub")
+	fs.Open("nested.txt")
+}
@@ -0,0 +1,7 @@
+package testdata
+
+import "github.com/markbates/pkger"
+
+func useInclude() {
+	pkger.Include("/assets/include.txt")
+}
@@ -0,0 +1,11 @@
+package testdata
+
+import (
+	"path"
+
+	"github.com/markbates/pkger"
+)
+
+func useJoin(file string) {
+	pkger.Open(path.Join("/migrations", file))
+}
@@ -0,0 +1,7 @@
+package testdata
+
+import "github.com/markbates/pkger"
+
+func useOpen() {
+	pkger.Open("/assets/open.txt")
+}
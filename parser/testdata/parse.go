@@ -0,0 +1,7 @@
+package testdata
+
+import "github.com/markbates/pkger"
+
+func useParse() {
+	pkger.Parse("/assets/parse.txt")
+}
@@ -0,0 +1,7 @@
+package testdata
+
+import "github.com/markbates/pkger"
+
+func useReadFile() {
+	pkger.ReadFile("/assets/readfile.txt")
+}
@@ -0,0 +1,7 @@
+package testdata
+
+import "github.com/markbates/pkger/parser/testdata/wrapper"
+
+func useRegistered() {
+	wrapper.MustOpen("/assets/registered.txt")
+}
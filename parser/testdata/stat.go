@@ -0,0 +1,7 @@
+package testdata
+
+import "github.com/markbates/pkger"
+
+func useStat() {
+	pkger.Stat("/assets/stat.txt")
+}
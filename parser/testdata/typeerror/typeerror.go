@@ -0,0 +1,12 @@
+// Package typeerror lives in its own subdirectory (rather than directly in
+// ./testdata alongside the other fixtures) specifically so its deliberate
+// type error doesn't poison package loading for every other fixture.
+package typeerror
+
+import "github.com/markbates/pkger"
+
+func useTypeError() {
+	pkger.Open("/assets/type_error.txt")
+	var x int = "not an int"
+	_ = x
+}
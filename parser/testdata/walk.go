@@ -0,0 +1,9 @@
+package testdata
+
+import (
+	"github.com/markbates/pkger"
+)
+
+func useWalk() {
+	pkger.Walk("/assets/walk", nil)
+}
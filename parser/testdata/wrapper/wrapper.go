@@ -0,0 +1,6 @@
+// Package wrapper stands in for a third-party library that wraps pkger's
+// own API, used to exercise RegisterPathFunc end-to-end.
+package wrapper
+
+// MustOpen mimics a project-local helper built on top of pkger.Open.
+func MustOpen(name string) {}
@@ -3,53 +3,138 @@ package parser
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
 	"strconv"
 	"strings"
 
+	"golang.org/x/tools/go/packages"
+
 	"github.com/markbates/pkger"
 	"github.com/markbates/pkger/here"
 	"github.com/markbates/pkger/pkging"
 )
 
+// pkgerImportPath is the fully-qualified import path of the pkger package
+// itself, used to resolve call targets by object identity rather than by
+// matching the literal identifier "pkger" (which breaks under import
+// aliasing).
+const pkgerImportPath = "github.com/markbates/pkger"
+
 type visitor struct {
-	File   string
-	Found  map[pkging.Path]bool
-	info   here.Info
-	errors []error
+	File        string
+	Found       map[pkging.Path]bool
+	info        here.Info
+	fset        *token.FileSet
+	types       *types.Info
+	pkg         *packages.Package
+	af          *ast.File
+	diagnostics []Diagnostic
 }
 
 func newVisitor(p string, info here.Info) (*visitor, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir: info.Dir,
+	}
+
+	pkgs, err := packages.Load(cfg, fmt.Sprintf("file=%s", p))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	if len(pkgs) == 0 || len(pkgs[0].Syntax) == 0 {
+		return nil, fmt.Errorf("%s: unable to load package", p)
+	}
+
+	pkg := pkgs[0]
+
+	var af *ast.File
+	for _, f := range pkg.Syntax {
+		if pkg.Fset.Position(f.Pos()).Filename == p {
+			af = f
+			break
+		}
+	}
+	if af == nil {
+		return nil, fmt.Errorf("%s: file not found in its own package", p)
+	}
+
+	// A type error in this package leaves pkg.TypesInfo incomplete, which
+	// makes Uses/Types lookups miss silently further down - exactly the
+	// failure mode this resolver was built to get rid of. Surface it as a
+	// diagnostic up front rather than pressing on as if nothing happened.
+	var diagnostics []Diagnostic
+	for _, pe := range pkg.Errors {
+		diagnostics = append(diagnostics, Diagnostic{
+			Pos:      packagesErrorPos(pe, p),
+			Severity: SeverityError,
+			Message:  pe.Msg,
+		})
+	}
+
 	return &visitor{
-		File:  p,
-		Found: map[pkging.Path]bool{},
-		info:  info,
+		File:        p,
+		Found:       map[pkging.Path]bool{},
+		info:        info,
+		fset:        pkg.Fset,
+		types:       pkg.TypesInfo,
+		pkg:         pkg,
+		af:          af,
+		diagnostics: diagnostics,
 	}, nil
 }
 
-func (v *visitor) Run() ([]pkging.Path, error) {
-	pf, err := parseFile(v.File)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %v", v.File, err)
+// packagesErrorPos parses the position go/packages.Error reports (it has
+// none of its own token.Pos, since it can originate before or outside
+// type-checking) into a token.Position. Per the packages doc, Pos is
+// "file:line" or "file:line:col", and may be empty or "-" for errors with
+// no position at all (e.g. a package that failed to load). This parses as
+// much of that as it can instead of requiring the full "file:line:col"
+// form, falling back to fallback (the file the caller asked to parse) only
+// when no filename is present.
+func packagesErrorPos(pe packages.Error, fallback string) token.Position {
+	parts := strings.Split(pe.Pos, ":")
+
+	pos := token.Position{Filename: fallback}
+	if len(parts) > 0 && parts[0] != "" && parts[0] != "-" {
+		pos.Filename = parts[0]
+	}
+	if len(parts) > 1 {
+		pos.Line, _ = strconv.Atoi(parts[1])
+	}
+	if len(parts) > 2 {
+		pos.Column, _ = strconv.Atoi(parts[2])
 	}
 
-	ast.Walk(v, pf.Ast)
+	return pos
+}
 
-	var found []pkging.Path
+// Run walks the file and returns every bundle path it discovered along with
+// any diagnostics raised while discovering them. It only returns a non-nil
+// error for problems that prevent walking the file at all; per-call
+// resolution failures are reported as Diagnostics instead.
+func (v *visitor) Run() (Result, error) {
+	ast.Walk(v, v.af)
 
+	var found []pkging.Path
 	for k := range v.Found {
 		found = append(found, k)
 	}
 
-	return found, nil
+	return Result{
+		Paths:       found,
+		Diagnostics: v.diagnostics,
+	}, nil
 }
 
 func (v *visitor) addPath(p string) error {
-	p, _ = strconv.Unquote(p)
 	pt, err := pkger.Parse(p)
 	if err != nil {
 		return err
 	}
-	if strings.HasPrefix(p, ":") {
+	if pt.Pkg == "" {
 		pt.Pkg = v.info.ImportPath
 	}
 
@@ -58,243 +143,151 @@ func (v *visitor) addPath(p string) error {
 	return nil
 }
 
+func (v *visitor) errorAt(pos token.Pos, callExpr ast.Expr, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Pos:      v.fset.Position(pos),
+		Severity: SeverityError,
+		Message:  fmt.Sprintf(format, args...),
+		CallExpr: types.ExprString(callExpr),
+	})
+}
+
+func (v *visitor) warnAt(pos token.Pos, callExpr ast.Expr, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Pos:      v.fset.Position(pos),
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf(format, args...),
+		CallExpr: types.ExprString(callExpr),
+	})
+}
+
 func (v *visitor) Visit(node ast.Node) ast.Visitor {
 	if node == nil {
 		return v
 	}
-	if err := v.eval(node); err != nil {
-		v.errors = append(v.errors, err)
-	}
+	v.eval(node)
 
 	return v
 }
 
-func (v *visitor) eval(node ast.Node) error {
-	switch t := node.(type) {
-	case *ast.CallExpr:
-		return v.evalExpr(t)
-	case *ast.Ident:
-		return v.evalIdent(t)
-	case *ast.GenDecl:
-		for _, n := range t.Specs {
-			if err := v.eval(n); err != nil {
-				return err
-			}
-		}
-	case *ast.FuncDecl:
-		if t.Body == nil {
-			return nil
-		}
-		for _, b := range t.Body.List {
-			if err := v.evalStmt(b); err != nil {
-				return err
-			}
-		}
-		return nil
-	case *ast.ValueSpec:
-		for _, e := range t.Values {
-			if err := v.evalExpr(e); err != nil {
-				return err
-			}
-		}
+func (v *visitor) eval(node ast.Node) {
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return
 	}
-	return nil
-}
 
-func (v *visitor) evalStmt(stmt ast.Stmt) error {
-	switch t := stmt.(type) {
-	case *ast.ExprStmt:
-		return v.evalExpr(t.X)
-	case *ast.AssignStmt:
-		for _, e := range t.Rhs {
-			if err := v.evalArgs(e); err != nil {
-				return err
-			}
-		}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return
 	}
-	return nil
+
+	v.evalSelector(call, sel)
 }
 
-func (v *visitor) evalExpr(expr ast.Expr) error {
-	switch t := expr.(type) {
-	case *ast.CallExpr:
-		if t.Fun == nil {
-			return nil
-		}
-		for _, a := range t.Args {
-			switch at := a.(type) {
-			case *ast.CallExpr:
-				if sel, ok := t.Fun.(*ast.SelectorExpr); ok {
-					return v.evalSelector(at, sel)
-				}
-
-				if err := v.evalArgs(at); err != nil {
-					return err
-				}
-			case *ast.CompositeLit:
-				for _, e := range at.Elts {
-					if err := v.evalExpr(e); err != nil {
-						return err
-					}
-				}
-			}
-		}
-		if ft, ok := t.Fun.(*ast.SelectorExpr); ok {
-			return v.evalSelector(t, ft)
-		}
-	case *ast.KeyValueExpr:
-		return v.evalExpr(t.Value)
+// resolveFunc returns the fully-qualified "importPath.FuncName" that sel
+// resolves to, using the type-checker's object resolution rather than
+// comparing the selector's identifier text. This correctly follows aliased
+// imports (`import p "github.com/markbates/pkger"`) and lets third-party
+// wrappers registered via RegisterPathFunc be recognized the same way as
+// the built-in pkger API.
+func (v *visitor) resolveFunc(sel *ast.SelectorExpr) (string, bool) {
+	obj, ok := v.types.Uses[sel.Sel]
+	if !ok {
+		return "", false
 	}
-	return nil
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return "", false
+	}
+	return fn.Pkg().Path() + "." + fn.Name(), true
 }
 
-func (v *visitor) evalArgs(expr ast.Expr) error {
-	switch at := expr.(type) {
-	case *ast.CompositeLit:
-		for _, e := range at.Elts {
-			if err := v.evalExpr(e); err != nil {
-				return err
-			}
-		}
-	case *ast.CallExpr:
-		if at.Fun == nil {
-			return nil
-		}
-		switch st := at.Fun.(type) {
-		case *ast.SelectorExpr:
-			if err := v.evalSelector(at, st); err != nil {
-				return err
-			}
-		case *ast.Ident:
-			return v.evalIdent(st)
-		}
-		for _, a := range at.Args {
-			if err := v.evalArgs(a); err != nil {
-				return err
-			}
-		}
+// isFileSystemOpen reports whether sel is a method value call shaped like
+// http.FileSystem's `Open(name string) (http.File, error)` - which covers
+// both calling .Open on an http.FileSystem adapter (e.g. one returned by
+// `pkger.Dir`) and calling .Open on a *pkger.File for nested/directory
+// access, without needing to import net/http or know pkger's concrete
+// return types: both are just values whose method set happens to match.
+func (v *visitor) isFileSystemOpen(sel *ast.SelectorExpr) bool {
+	if sel.Sel.Name != "Open" {
+		return false
 	}
-	return nil
-}
 
-func (v *visitor) evalSelector(expr *ast.CallExpr, sel *ast.SelectorExpr) error {
-	x, ok := sel.X.(*ast.Ident)
-	if !ok {
-		return nil
+	s, ok := v.types.Selections[sel]
+	if !ok || s.Kind() != types.MethodVal {
+		return false
 	}
-	if x.Name == "pkger" {
-		switch sel.Sel.Name {
-		case "Walk":
-			if len(expr.Args) != 2 {
-				return fmt.Errorf("`Walk` requires two arguments")
-			}
-
-			zz := func(e ast.Expr) (string, error) {
-				switch at := e.(type) {
-				case *ast.Ident:
-					switch at.Obj.Kind {
-					case ast.Var:
-						if as, ok := at.Obj.Decl.(*ast.AssignStmt); ok {
-							return v.fromVariable(as)
-						}
-					case ast.Con:
-						if vs, ok := at.Obj.Decl.(*ast.ValueSpec); ok {
-							return v.fromConstant(vs)
-						}
-					}
-					return "", v.evalIdent(at)
-				case *ast.BasicLit:
-					return at.Value, nil
-				case *ast.CallExpr:
-					return "", v.evalExpr(at)
-				}
-				return "", fmt.Errorf("can't handle %T", e)
-			}
-
-			k1, err := zz(expr.Args[0])
-			if err != nil {
-				return err
-			}
-			if err := v.addPath(k1); err != nil {
-				return err
-			}
-
-			return nil
-		case "Open":
-			for _, e := range expr.Args {
-				switch at := e.(type) {
-				case *ast.Ident:
-					switch at.Obj.Kind {
-					case ast.Var:
-						if as, ok := at.Obj.Decl.(*ast.AssignStmt); ok {
-							v.addVariable("", as)
-						}
-					case ast.Con:
-						if vs, ok := at.Obj.Decl.(*ast.ValueSpec); ok {
-							v.addConstant("", vs)
-						}
-					}
-					return v.evalIdent(at)
-				case *ast.BasicLit:
-					return v.addPath(at.Value)
-				case *ast.CallExpr:
-					return v.evalExpr(at)
-				}
-			}
-		}
+
+	sig, ok := s.Type().(*types.Signature)
+	if !ok || sig.Params().Len() != 1 || sig.Results().Len() != 2 {
+		return false
 	}
 
-	return nil
+	return types.Identical(sig.Params().At(0).Type(), types.Typ[types.String])
 }
 
-func (v *visitor) evalIdent(i *ast.Ident) error {
-	if i.Obj == nil {
-		return nil
+func (v *visitor) evalSelector(expr *ast.CallExpr, sel *ast.SelectorExpr) {
+	if v.isFileSystemOpen(sel) {
+		if len(expr.Args) != 1 {
+			return
+		}
+		v.evalPathArg(expr, types.ExprString(sel), expr.Args[0])
+		return
 	}
-	if s, ok := i.Obj.Decl.(*ast.AssignStmt); ok {
-		return v.evalStmt(s)
+
+	key, ok := v.resolveFunc(sel)
+	if !ok {
+		return
 	}
-	return nil
-}
 
-func (v *visitor) fromVariable(as *ast.AssignStmt) (string, error) {
-	if len(as.Rhs) == 1 {
-		if bs, ok := as.Rhs[0].(*ast.BasicLit); ok {
-			return bs.Value, nil
-		}
+	ex, ok := pathFuncs[key]
+	if !ok {
+		return
 	}
-	return "", fmt.Errorf("unable to find value from variable %v", as)
-}
 
-func (v *visitor) addVariable(bn string, as *ast.AssignStmt) error {
-	bv, err := v.fromVariable(as)
-	if err != nil {
-		return nil
+	if ex.Arity != 0 && len(expr.Args) != ex.Arity {
+		v.errorAt(expr.Pos(), expr, "`%s` requires %d arguments", key, ex.Arity)
+		return
 	}
-	if len(bn) == 0 {
-		bn = bv
+	if ex.ArgIndex >= len(expr.Args) {
+		v.errorAt(expr.Pos(), expr, "`%s` has no argument at index %d", key, ex.ArgIndex)
+		return
 	}
-	return v.addPath(bn)
+
+	v.evalPathArg(expr, key, expr.Args[ex.ArgIndex])
 }
 
-func (v *visitor) fromConstant(vs *ast.ValueSpec) (string, error) {
-	if len(vs.Values) == 1 {
-		if bs, ok := vs.Values[0].(*ast.BasicLit); ok {
-			return bs.Value, nil
-		}
+// evalPathArg resolves arg (the path-carrying argument of a call to key -
+// either a registered pathFuncs entry or an http.FileSystem-shaped Open
+// method) and records the result, warning when only a constant prefix of
+// arg could be determined.
+func (v *visitor) evalPathArg(expr *ast.CallExpr, key string, arg ast.Expr) {
+	p, complete, ok := v.resolvePrefix(arg)
+	if !ok {
+		v.errorAt(expr.Pos(), expr, "unable to resolve constant path for `%s`", key)
+		return
+	}
+	if !complete {
+		v.warnAt(expr.Pos(), expr, "`%s` argument is not fully constant, bundling longest constant prefix %q as a directory", key, p)
+	}
+
+	if err := v.addPath(p); err != nil {
+		v.errorAt(expr.Pos(), expr, "%v", err)
 	}
-	return "", fmt.Errorf("unable to find value from constant %v", vs)
 }
 
-func (v *visitor) addConstant(bn string, vs *ast.ValueSpec) error {
-	if len(vs.Values) == 1 {
-		if bs, ok := vs.Values[0].(*ast.BasicLit); ok {
-			bv := bs.Value
-			if len(bn) == 0 {
-				bn = bv
-			}
-			return v.addPath(bn)
-		}
+// constString resolves expr to a constant string value using the
+// type-checker's constant folding, which handles literals, named
+// constants (local or imported, same-file or cross-file), binary `+`
+// expressions, and parenthesized forms uniformly.
+func (v *visitor) constString(expr ast.Expr) (string, bool) {
+	tv, ok := v.types.Types[expr]
+	if !ok || tv.Value == nil {
+		return "", false
 	}
-	return nil
+	if tv.Value.Kind() != constant.String {
+		return "", false
+	}
+
+	return constant.StringVal(tv.Value), true
 }
@@ -0,0 +1,103 @@
+package costello
+
+import (
+	"compress/gzip"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/markbates/pkger/pkging/zipfs"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Zipfs_RoundTrip(t *testing.T) {
+	r := require.New(t)
+
+	ref, err := NewRef()
+	r.NoError(err)
+
+	pkg, err := zipfs.New(ref.Info)
+	r.NoError(err)
+
+	CreateTest(t, pkg)
+}
+
+func Test_Zipfs_RoundTrip_NoCompression(t *testing.T) {
+	r := require.New(t)
+
+	ref, err := NewRef()
+	r.NoError(err)
+
+	pkg, err := zipfs.New(ref.Info)
+	r.NoError(err)
+	pkg.SetCompression(gzip.NoCompression)
+
+	CreateTest(t, pkg)
+}
+
+func Test_Zipfs_Walk_NameIsBaseName(t *testing.T) {
+	r := require.New(t)
+
+	ref, err := NewRef()
+	r.NoError(err)
+
+	pkg, err := zipfs.New(ref.Info)
+	r.NoError(err)
+
+	f, err := pkg.Create("/assets/sub/nested.txt")
+	r.NoError(err)
+	_, err = f.Write([]byte("hello"))
+	r.NoError(err)
+	r.NoError(f.Close())
+
+	var names []string
+	r.NoError(pkg.Walk("/", func(path string, info os.FileInfo, err error) error {
+		r.NoError(err)
+		names = append(names, info.Name())
+		return nil
+	}))
+
+	r.Contains(names, "nested.txt")
+}
+
+// Test_Zipfs_Walk_CallbackReentersZipfs guards against a deadlock: Walk
+// must not hold its lock while invoking the caller's filepath.WalkFunc,
+// since a walk-then-open is a completely normal thing for a callback to do.
+func Test_Zipfs_Walk_CallbackReentersZipfs(t *testing.T) {
+	r := require.New(t)
+
+	ref, err := NewRef()
+	r.NoError(err)
+
+	pkg, err := zipfs.New(ref.Info)
+	r.NoError(err)
+
+	f, err := pkg.Create("/assets/reentrant.txt")
+	r.NoError(err)
+	_, err = f.Write([]byte("hello"))
+	r.NoError(err)
+	r.NoError(f.Close())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- pkg.Walk("/", func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if _, err = pkg.Open(path); err != nil {
+				return err
+			}
+			// SetCompression takes the write lock, so this blocks for good
+			// if Walk is still holding its read lock around the callback.
+			pkg.SetCompression(gzip.DefaultCompression)
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		r.NoError(err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Walk deadlocked calling back into Zipfs from its WalkFunc")
+	}
+}
@@ -0,0 +1,59 @@
+package zipfs
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"github.com/markbates/pkger/pkging"
+)
+
+// Entry is the in-memory record for a single bundled file: its bytes as
+// stored (gzip-compressed unless the Zipfs compression level was
+// gzip.NoCompression when the entry was stored), plus enough metadata to
+// satisfy os.FileInfo and to know whether Read needs to go through a
+// gzip.Reader.
+type Entry struct {
+	Path            pkging.Path
+	Data            []byte
+	Compressed      bool
+	UncompressedLen int64
+	Mode            os.FileMode
+	ModTime         time.Time
+}
+
+func (e *Entry) info() os.FileInfo {
+	return entryInfo{e}
+}
+
+type entryInfo struct {
+	e *Entry
+}
+
+// Name returns the leaf component of the entry's virtual path, matching
+// what every other os.FileInfo implementation (and filepath.WalkFunc
+// callers doing the standard filepath.Base(path) == info.Name() check)
+// expects - not the full path itself.
+func (i entryInfo) Name() string {
+	return path.Base(i.e.Path.Name)
+}
+
+func (i entryInfo) Size() int64 {
+	return i.e.UncompressedLen
+}
+
+func (i entryInfo) Mode() os.FileMode {
+	return i.e.Mode
+}
+
+func (i entryInfo) ModTime() time.Time {
+	return i.e.ModTime
+}
+
+func (i entryInfo) IsDir() bool {
+	return i.e.Mode.IsDir()
+}
+
+func (i entryInfo) Sys() interface{} {
+	return i.e
+}
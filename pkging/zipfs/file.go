@@ -0,0 +1,75 @@
+package zipfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// file adapts an Entry to pkging.File. Reads transparently stream through a
+// gzip.Reader when the entry was stored compressed; Stat always reports the
+// uncompressed size, never the on-disk footprint.
+type file struct {
+	entry  *Entry
+	reader io.ReadCloser
+	buf    *bytes.Reader
+}
+
+func newFile(e *Entry) (*file, error) {
+	f := &file{entry: e}
+
+	if !e.Compressed {
+		f.buf = bytes.NewReader(e.Data)
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(e.Data))
+	if err != nil {
+		return nil, fmt.Errorf("zipfs: %s: %v", e.Path, err)
+	}
+	f.reader = gz
+
+	return f, nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.buf != nil {
+		return f.buf.Read(p)
+	}
+	return f.reader.Read(p)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.buf == nil {
+		return 0, fmt.Errorf("zipfs: %s: Seek is not supported on compressed entries", f.entry.Path)
+	}
+	return f.buf.Seek(offset, whence)
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("zipfs: %s: file is read-only", f.entry.Path)
+}
+
+func (f *file) Close() error {
+	if f.reader != nil {
+		return f.reader.Close()
+	}
+	return nil
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	return f.entry.info(), nil
+}
+
+// Name returns the leaf component of the entry's virtual path, not the
+// full path.
+func (f *file) Name() string {
+	return path.Base(f.entry.Path.Name)
+}
+
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, fmt.Errorf("zipfs: %s: not a directory", f.entry.Path)
+}
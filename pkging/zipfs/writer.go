@@ -0,0 +1,77 @@
+package zipfs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/markbates/pkger/pkging"
+)
+
+// writer is the pkging.File handle returned by Zipfs.Create. It buffers
+// writes in the open; Close is what actually gzip-compresses and stores the
+// finished bytes, since the entry's compressed length isn't known until the
+// whole file has been written.
+type writer struct {
+	fs     *Zipfs
+	path   pkging.Path
+	mode   os.FileMode
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newWriter(fs *Zipfs, p pkging.Path, mode os.FileMode) *writer {
+	return &writer{fs: fs, path: p, mode: mode}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("zipfs: %s: file already closed", w.path)
+	}
+	return w.buf.Write(p)
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.fs.store(w.path, w.buf.Bytes(), w.mode, time.Now())
+}
+
+func (w *writer) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("zipfs: %s: file is write-only", w.path)
+}
+
+func (w *writer) Seek(offset int64, whence int) (int64, error) {
+	return 0, fmt.Errorf("zipfs: %s: Seek is not supported while writing", w.path)
+}
+
+func (w *writer) Stat() (os.FileInfo, error) {
+	return writerInfo{w}, nil
+}
+
+// Name returns the leaf component of the path being written, not the full
+// path.
+func (w *writer) Name() string {
+	return path.Base(w.path.Name)
+}
+
+func (w *writer) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, fmt.Errorf("zipfs: %s: not a directory", w.path)
+}
+
+// writerInfo reports the size written so far, since the entry isn't
+// recorded in Zipfs until Close.
+type writerInfo struct {
+	w *writer
+}
+
+func (i writerInfo) Name() string       { return path.Base(i.w.path.Name) }
+func (i writerInfo) Size() int64        { return int64(i.w.buf.Len()) }
+func (i writerInfo) Mode() os.FileMode  { return i.w.mode }
+func (i writerInfo) ModTime() time.Time { return time.Time{} }
+func (i writerInfo) IsDir() bool        { return i.w.mode.IsDir() }
+func (i writerInfo) Sys() interface{}   { return i.w }
@@ -0,0 +1,276 @@
+// Package zipfs is an in-memory pkging.Pkger backend that stores each
+// bundled file gzip-compressed (or as plain bytes at gzip.NoCompression),
+// where pkging/mem keeps raw bytes, and decompresses on Read. Entries are
+// kept in a map keyed by path; the package does not serialize them into a
+// length-prefixed bundle or write a manifest header.
+package zipfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/markbates/pkger"
+	"github.com/markbates/pkger/here"
+	"github.com/markbates/pkger/pkging"
+)
+
+// Zipfs is an in-memory, gzip-backed pkging.Pkger. Nothing in this tree
+// generates code that decodes into it; it is populated through Create and
+// MkdirAll.
+type Zipfs struct {
+	info        here.Info
+	compression int
+
+	mu      sync.RWMutex
+	entries map[pkging.Path]*Entry
+}
+
+// New returns an empty Zipfs rooted at info, ready to have files written
+// into it via Create. Compression defaults to gzip.DefaultCompression; use
+// SetCompression to change it. New does not take a pkger.BuildOptions.
+func New(info here.Info) (*Zipfs, error) {
+	return &Zipfs{
+		info:        info,
+		compression: gzip.DefaultCompression,
+		entries:     map[pkging.Path]*Entry{},
+	}, nil
+}
+
+// SetCompression overrides the gzip level used for entries stored after this
+// call; a file from Create is stored when it is Closed, so the level in
+// effect at Close is the one applied. A level of gzip.NoCompression stores
+// entries as plain bytes, matching the historical pkging/mem behavior.
+func (fs *Zipfs) SetCompression(level int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.compression = level
+}
+
+func (fs *Zipfs) parse(name string) (pkging.Path, error) {
+	p, err := pkger.Parse(name)
+	if err != nil {
+		return pkging.Path{}, err
+	}
+	if p.Pkg == "" {
+		p.Pkg = fs.info.ImportPath
+	}
+	return p, nil
+}
+
+// Create returns a pkging.File open for writing at name. The bytes written
+// to it are gzip-compressed (unless the configured level is
+// gzip.NoCompression) and stored under the entry once the file is Closed,
+// mirroring os.Create.
+func (fs *Zipfs) Create(name string) (pkging.File, error) {
+	p, err := fs.parse(name)
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(fs, p, 0644), nil
+}
+
+// store compresses b (unless compression is gzip.NoCompression) and records
+// it under p. It is called by a writer's Close, once the full contents of
+// the file are known.
+func (fs *Zipfs) store(p pkging.Path, b []byte, mode os.FileMode, modTime time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	e := &Entry{
+		Path:            p,
+		UncompressedLen: int64(len(b)),
+		Mode:            mode,
+		ModTime:         modTime,
+	}
+
+	if fs.compression == gzip.NoCompression {
+		e.Data = b
+		fs.entries[p] = e
+		return nil
+	}
+
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, fs.compression)
+	if err != nil {
+		return fmt.Errorf("zipfs: %s: %v", p, err)
+	}
+	if _, err := gz.Write(b); err != nil {
+		return fmt.Errorf("zipfs: %s: %v", p, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("zipfs: %s: %v", p, err)
+	}
+
+	e.Data = buf.Bytes()
+	e.Compressed = true
+	fs.entries[p] = e
+
+	return nil
+}
+
+// MkdirAll records an empty directory entry at name, the way pkging/mem
+// does, so Walk and Stat see it even before any file is created under it.
+func (fs *Zipfs) MkdirAll(name string, perm os.FileMode) error {
+	p, err := fs.parse(name)
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.entries[p] = &Entry{
+		Path:    p,
+		Mode:    perm | os.ModeDir,
+		ModTime: time.Now(),
+	}
+
+	return nil
+}
+
+// Remove deletes the single entry at name.
+func (fs *Zipfs) Remove(name string) error {
+	p, err := fs.parse(name)
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, ok := fs.entries[p]; !ok {
+		return os.ErrNotExist
+	}
+	delete(fs.entries, p)
+
+	return nil
+}
+
+// RemoveAll deletes name and every entry nested under it.
+func (fs *Zipfs) RemoveAll(name string) error {
+	p, err := fs.parse(name)
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for k := range fs.entries {
+		if pathHasPrefix(k.Name, p.Name) {
+			delete(fs.entries, k)
+		}
+	}
+
+	return nil
+}
+
+// Rename moves the entry at oldname to newname.
+func (fs *Zipfs) Rename(oldname, newname string) error {
+	op, err := fs.parse(oldname)
+	if err != nil {
+		return err
+	}
+	np, err := fs.parse(newname)
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	e, ok := fs.entries[op]
+	if !ok {
+		return os.ErrNotExist
+	}
+	delete(fs.entries, op)
+	e.Path = np
+	fs.entries[np] = e
+
+	return nil
+}
+
+func (fs *Zipfs) find(name string) (*Entry, error) {
+	p, err := fs.parse(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	e, ok := fs.entries[p]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return e, nil
+}
+
+// Open returns a pkging.File whose Read transparently streams through
+// gzip.Reader for entries that were stored compressed. Stat always reports
+// the uncompressed size.
+func (fs *Zipfs) Open(name string) (pkging.File, error) {
+	e, err := fs.find(name)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(e)
+}
+
+// Stat reports the uncompressed size of the entry at name, never its
+// on-disk, possibly-gzipped length.
+func (fs *Zipfs) Stat(name string) (os.FileInfo, error) {
+	e, err := fs.find(name)
+	if err != nil {
+		return nil, err
+	}
+	return e.info(), nil
+}
+
+// Current returns the here.Info this Zipfs was built against.
+func (fs *Zipfs) Current() (here.Info, error) {
+	return fs.info, nil
+}
+
+func (fs *Zipfs) Walk(root string, wf filepath.WalkFunc) error {
+	// Snapshot the matching entries while holding the lock, then call wf
+	// after releasing it: wf is caller-supplied and routinely calls back
+	// into this Zipfs (e.g. Open/Stat on the entry it was just handed),
+	// which would re-enter RLock while this RLock is still held. That
+	// deadlocks as soon as a writer is queued in between the two RLocks,
+	// since sync.RWMutex doesn't support recursive read locking.
+	type match struct {
+		name string
+		info os.FileInfo
+	}
+
+	fs.mu.RLock()
+	matches := make([]match, 0, len(fs.entries))
+	for p, e := range fs.entries {
+		if !pathHasPrefix(p.Name, root) {
+			continue
+		}
+		matches = append(matches, match{name: p.Name, info: e.info()})
+	}
+	fs.mu.RUnlock()
+
+	for _, m := range matches {
+		if err := wf(m.name, m.info, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pathHasPrefix(name, prefix string) bool {
+	if prefix == "" || prefix == "/" {
+		return true
+	}
+	return name == prefix || (len(name) > len(prefix) && name[:len(prefix)] == prefix && name[len(prefix)] == '/')
+}